@@ -2,8 +2,18 @@ package main
 
 import (
 	"fmt"
+	"os"
+
+	"github.com/FollowTheProcess/glox/internal/cli"
+	"github.com/FollowTheProcess/hue"
 )
 
+// errorStyle is the style of the "Error" prefix on fatal errors.
+const errorStyle = hue.Red | hue.Bold
+
 func main() {
-	fmt.Println("Don't communicate by sharing memory, share memory by communicating.")
+	if err := cli.New(os.Stdin, os.Stdout, os.Stderr).Run(os.Args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", errorStyle.Text("Error"), err)
+		os.Exit(1)
+	}
 }
@@ -2,10 +2,10 @@ module github.com/FollowTheProcess/glox
 
 go 1.24
 
-require github.com/FollowTheProcess/test v0.21.0
-
 require (
-	github.com/FollowTheProcess/hue v0.5.2 // indirect
-	golang.org/x/sys v0.31.0 // indirect
-	golang.org/x/term v0.30.0 // indirect
+	github.com/FollowTheProcess/hue v0.5.2
+	github.com/FollowTheProcess/test v0.21.0
+	golang.org/x/term v0.30.0
 )
+
+require golang.org/x/sys v0.31.0 // indirect
@@ -0,0 +1,271 @@
+// Package cli implements the glox command line interface.
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"strconv"
+
+	"github.com/FollowTheProcess/glox/internal/syntax"
+	"github.com/FollowTheProcess/hue"
+	"golang.org/x/term"
+)
+
+// stdinName is the name given to source read from stdin, used in diagnostics.
+const stdinName = "<stdin>"
+
+// positionStyle is the style applied to source positions in diagnostics, when colour is enabled.
+const positionStyle = hue.Bold
+
+// usage is the top level help text for glox.
+const usage = `An implementation of the Lox language from Crafting Interpreters, written in Go
+
+Usage: glox [flags] <command> [flags] [args...]
+
+Commands:
+  completion  Print a shell completion script for glox
+  gen         Generate a random, syntactically valid Lox program
+  highlight   Print a Lox file with syntax highlighting
+  tokens      Print the token stream of a Lox file
+  version     Print glox version and build information
+
+Flags:
+  --color string    When to use colour, one of auto, always or never (default "auto")
+  -h, --help        Show this help and exit
+  -v, -vv           Log progress (-v) or debug information (-vv) to stderr
+  --version         Show the version and exit
+
+With --color auto, colour is only used when writing to a terminal, unless
+$NO_COLOR is set. $FORCE_COLOR enables colour regardless.
+
+Run 'glox <command> --help' for more information on a command.
+`
+
+// App is the glox command line application.
+type App struct {
+	stdin  io.Reader    // Source of input when reading from "-"
+	stdout io.Writer    // Normal program output
+	stderr io.Writer    // Diagnostics and usage
+	logger *slog.Logger // Verbose logging, discarded unless -v is passed
+	color  string       // The --color mode, one of auto, always or never
+}
+
+// New returns a new [App] reading from stdin, writing output to stdout and
+// diagnostics to stderr.
+func New(stdin io.Reader, stdout, stderr io.Writer) App {
+	return App{
+		stdin:  stdin,
+		stdout: stdout,
+		stderr: stderr,
+		logger: slog.New(slog.DiscardHandler),
+		color:  "auto",
+	}
+}
+
+// verbosity is a repeatable flag counting how verbose logging should be.
+type verbosity int
+
+// String implements [flag.Value] for a [verbosity].
+func (v *verbosity) String() string {
+	return strconv.Itoa(int(*v))
+}
+
+// Set implements [flag.Value] for a [verbosity], every true occurrence increases it by one.
+func (v *verbosity) Set(value string) error {
+	on, err := strconv.ParseBool(value)
+	if err != nil {
+		return err
+	}
+	if on {
+		*v++
+	}
+	return nil
+}
+
+// IsBoolFlag allows the flag to be passed without a value.
+func (v *verbosity) IsBoolFlag() bool {
+	return true
+}
+
+// level returns the log level corresponding to the verbosity.
+func (v verbosity) level() slog.Level {
+	switch {
+	case v >= 2:
+		return slog.LevelDebug
+	case v == 1:
+		return slog.LevelInfo
+	default:
+		return slog.LevelWarn
+	}
+}
+
+// colorModes are the accepted values of --color.
+var colorModes = []string{"auto", "always", "never"}
+
+// rootFlags returns the flags accepted by glox itself, before any command.
+func rootFlags(color *string, showVersion *bool, verbose *verbosity) *flag.FlagSet {
+	flags := flag.NewFlagSet("glox", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), usage)
+	}
+
+	flags.StringVar(color, "color", *color, "When to use colour, one of auto, always or never")
+	flags.BoolVar(showVersion, "version", false, "Show the version and exit")
+	flags.Var(verbose, "v", "Log progress to stderr, repeat for debug information")
+	flags.BoolFunc("vv", "Log debug information to stderr", func(value string) error {
+		on, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		if on {
+			*verbose += 2
+		}
+		return nil
+	})
+
+	return flags
+}
+
+// Run runs the command line application with the given arguments, which
+// should not include the program name.
+func (a App) Run(args []string) error {
+	// Until we know otherwise, errors (including bad flags) should follow the default
+	hue.Enabled(a.useColor(a.stderr))
+
+	var (
+		showVersion bool
+		verbose     verbosity
+	)
+	flags := rootFlags(&a.color, &showVersion, &verbose)
+
+	if done, err := a.parseFlags(flags, args); done {
+		return err
+	}
+
+	if !slices.Contains(colorModes, a.color) {
+		return fmt.Errorf("invalid --color %q, expected one of auto, always or never", a.color)
+	}
+
+	// The only coloured output is diagnostics, which go to stderr
+	hue.Enabled(a.useColor(a.stderr))
+
+	if verbose > 0 {
+		a.logger = slog.New(slog.NewTextHandler(a.stderr, &slog.HandlerOptions{Level: verbose.level()}))
+	}
+
+	if showVersion {
+		fmt.Fprintf(a.stdout, "glox %s\n", getBuildInfo().Version)
+		return nil
+	}
+
+	if flags.NArg() == 0 {
+		fmt.Fprint(a.stderr, usage)
+		return errors.New("no command given")
+	}
+
+	command, rest := flags.Arg(0), flags.Args()[1:]
+	a.logger = a.logger.With("command", command)
+	a.logger.Debug("running command", "args", rest)
+
+	switch command {
+	case "help":
+		fmt.Fprint(a.stdout, usage)
+		return nil
+	case "completion":
+		return a.completion(rest)
+	case "gen":
+		return a.gen(rest)
+	case "highlight":
+		return a.highlight(rest)
+	case "tokens":
+		return a.tokens(rest)
+	case "version":
+		return a.version(rest)
+	default:
+		return fmt.Errorf("unknown command %q, run 'glox --help' to see the available commands", command)
+	}
+}
+
+// parseFlags parses args into flags, reporting whether the command is done, either because
+// help was requested or because of an error.
+//
+// Explicitly requested help is written to stdout. On a parse error the usage is written to
+// stderr and the error returned, without the flag package's own copy of the error message
+// so it is only reported once.
+func (a App) parseFlags(flags *flag.FlagSet, args []string) (done bool, err error) {
+	usage := flags.Usage
+	flags.Usage = func() {}
+	flags.SetOutput(io.Discard)
+
+	err = flags.Parse(args)
+	switch {
+	case err == nil:
+		return false, nil
+	case errors.Is(err, flag.ErrHelp):
+		flags.SetOutput(a.stdout)
+		usage()
+		return true, nil
+	default:
+		flags.SetOutput(a.stderr)
+		usage()
+		return true, err
+	}
+}
+
+// useColor reports whether output written to w should be coloured according to
+// the --color mode.
+//
+// In auto mode this is decided by the stream actually being written to rather than
+// stdout, $FORCE_COLOR always enables colour and $NO_COLOR or TERM=dumb disable it.
+func (a App) useColor(w io.Writer) bool {
+	switch a.color {
+	case "always":
+		return true
+	case "never":
+		return false
+	}
+
+	switch {
+	case os.Getenv("FORCE_COLOR") != "":
+		return true
+	case os.Getenv("NO_COLOR") != "", os.Getenv("TERM") == "dumb":
+		return false
+	}
+
+	f, ok := w.(*os.File)
+	return ok && term.IsTerminal(int(f.Fd()))
+}
+
+// readSource reads the Lox source at path, where a path of "-" reads from stdin.
+//
+// It returns the name the source should be referred to by in diagnostics, along
+// with its contents.
+func (a App) readSource(path string) (name, src string, err error) {
+	if path == "-" {
+		contents, err := io.ReadAll(a.stdin)
+		if err != nil {
+			return "", "", fmt.Errorf("could not read from stdin: %w", err)
+		}
+		a.logger.Debug("read source", "file", stdinName, "bytes", len(contents))
+		return stdinName, string(contents), nil
+	}
+
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return "", "", err
+	}
+
+	a.logger.Debug("read source", "file", path, "bytes", len(contents))
+
+	return path, string(contents), nil
+}
+
+// diagnostic reports a syntax error at pos to stderr.
+func (a App) diagnostic(pos syntax.Position, msg string) {
+	fmt.Fprintf(a.stderr, "%s: %s\n", positionStyle.Text(pos.String()), msg)
+}
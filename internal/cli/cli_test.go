@@ -0,0 +1,605 @@
+package cli_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/FollowTheProcess/glox/internal/cli"
+	"github.com/FollowTheProcess/hue"
+	"github.com/FollowTheProcess/test"
+)
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name    string   // Name of the test case
+		args    []string // Arguments to pass to Run
+		stdout  string   // Expected stdout
+		wantErr bool     // Whether Run should return an error
+	}{
+		{
+			name:    "no args",
+			args:    nil,
+			wantErr: true,
+		},
+		{
+			name:    "unknown command",
+			args:    []string{"unknown"},
+			wantErr: true,
+		},
+		{
+			name:   "help",
+			args:   []string{"--help"},
+			stdout: "An implementation of the Lox language from Crafting Interpreters, written in Go\n",
+		},
+		{
+			name:   "help command",
+			args:   []string{"help"},
+			stdout: "An implementation of the Lox language from Crafting Interpreters, written in Go\n",
+		},
+		{
+			name:    "bad color",
+			args:    []string{"--color", "sometimes", "tokens", "-"},
+			wantErr: true,
+		},
+		{
+			name:    "color without command",
+			args:    []string{"--color", "never"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout := &bytes.Buffer{}
+			stderr := &bytes.Buffer{}
+
+			err := cli.New(strings.NewReader(""), stdout, stderr).Run(tt.args)
+			test.WantErr(t, err, tt.wantErr)
+
+			if tt.stdout != "" {
+				test.True(t, bytes.HasPrefix(stdout.Bytes(), []byte(tt.stdout)), test.Context("stdout was %q", stdout.String()))
+			}
+		})
+	}
+}
+
+func TestTokens(t *testing.T) {
+	tests := []struct {
+		name    string   // Name of the test case
+		src     string   // Lox source to write to the file
+		args    []string // Arguments to 'glox tokens', the file path is appended
+		stdout  string   // Expected stdout
+		stderr  string   // Expected stderr
+		wantErr bool     // Whether Run should return an error
+	}{
+		{
+			name: "table",
+			src:  "var x = \"hi\";\nprint x;",
+			stdout: `POSITION  KIND       LEXEME
+1:1       Var        "var"
+1:5       Ident      "x"
+1:7       Eq         "="
+1:9       String     "\"hi\""
+1:13      SemiColon  ";"
+2:1       Print      "print"
+2:7       Ident      "x"
+2:8       SemiColon  ";"
+`,
+		},
+		{
+			name: "json",
+			src:  "x\n  >=",
+			args: []string{"--format", "json"},
+			stdout: `[
+  {
+    "kind": "Ident",
+    "lexeme": "x",
+    "line": 1,
+    "column": 1,
+    "start": 0,
+    "end": 1
+  },
+  {
+    "kind": "GreaterEq",
+    "lexeme": ">=",
+    "line": 2,
+    "column": 3,
+    "start": 4,
+    "end": 6
+  }
+]
+`,
+		},
+		{
+			name:   "json empty",
+			src:    "// Nothing but a comment",
+			args:   []string{"--format", "json"},
+			stdout: "[]\n",
+		},
+		{
+			name:   "raw",
+			src:    "(1)",
+			args:   []string{"--format", "raw"},
+			stdout: "<Token::OpenParen line=1 start=0 end=1>\n<Token::Number line=1 start=1 end=2>\n<Token::CloseParen line=1 start=2 end=3>\n",
+		},
+		{
+			name: "multiline string",
+			src:  "\"one\ntwo\" x",
+			args: []string{"--format", "table"},
+			stdout: `POSITION  KIND    LEXEME
+1:1       String  "\"one\ntwo\""
+2:6       Ident   "x"
+`,
+		},
+		{
+			name:   "multiline string raw",
+			src:    "\"one\ntwo\" x",
+			args:   []string{"--format", "raw"},
+			stdout: "<Token::String line=1 start=0 end=9>\n<Token::Ident line=2 start=10 end=11>\n",
+		},
+		{
+			name:    "syntax errors",
+			src:     "a $",
+			args:    []string{"--format", "raw"},
+			stdout:  "<Token::Ident line=1 start=0 end=1>\n<Token::Error line=1 start=2 end=3>\n",
+			stderr:  "FILE:1:3-4: unexpected character '$'\n",
+			wantErr: true,
+		},
+		{
+			name:    "bad format",
+			src:     "a",
+			args:    []string{"--format", "yaml"},
+			wantErr: true,
+		},
+		{
+			name:    "too many args",
+			src:     "a",
+			args:    []string{"another.lox"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "test.lox")
+			test.Ok(t, os.WriteFile(file, []byte(tt.src), 0o644))
+
+			stdout := &bytes.Buffer{}
+			stderr := &bytes.Buffer{}
+
+			// Diagnostics are checked as plain text whatever the colour settings of the shell running the tests
+			args := append([]string{"--color", "never", "tokens"}, tt.args...)
+			args = append(args, file)
+
+			err := cli.New(strings.NewReader(""), stdout, stderr).Run(args)
+			test.WantErr(t, err, tt.wantErr)
+
+			test.Equal(t, stdout.String(), tt.stdout)
+			if tt.stderr != "" {
+				test.Equal(t, stderr.String(), strings.ReplaceAll(tt.stderr, "FILE", file))
+			}
+		})
+	}
+}
+
+func TestTokensStdin(t *testing.T) {
+	stdin := strings.NewReader("x $")
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+
+	err := cli.New(stdin, stdout, stderr).Run([]string{"--color", "never", "tokens", "--format", "raw", "-"})
+	test.Err(t, err)
+
+	test.Equal(t, stdout.String(), "<Token::Ident line=1 start=0 end=1>\n<Token::Error line=1 start=2 end=3>\n")
+	test.Equal(t, stderr.String(), "<stdin>:1:3-4: unexpected character '$'\n")
+	test.Equal(t, err.Error(), "<stdin>: found 1 syntax error(s)")
+}
+
+func TestColor(t *testing.T) {
+	tests := []struct {
+		env    map[string]string // Environment variables to set
+		name   string            // Name of the test case
+		color  string            // Value of the --color flag
+		stderr string            // Expected stderr
+	}{
+		{
+			name:   "always",
+			color:  "always",
+			stderr: "\x1b[1m<stdin>:1:1-2\x1b[0m: unexpected character '$'\n",
+		},
+		{
+			name:   "never",
+			color:  "never",
+			stderr: "<stdin>:1:1-2: unexpected character '$'\n",
+		},
+		{
+			name:   "auto not a terminal",
+			color:  "auto",
+			stderr: "<stdin>:1:1-2: unexpected character '$'\n",
+		},
+		{
+			name:   "auto force color",
+			color:  "auto",
+			env:    map[string]string{"FORCE_COLOR": "1"},
+			stderr: "\x1b[1m<stdin>:1:1-2\x1b[0m: unexpected character '$'\n",
+		},
+		{
+			name:   "auto no color",
+			color:  "auto",
+			env:    map[string]string{"NO_COLOR": "1"},
+			stderr: "<stdin>:1:1-2: unexpected character '$'\n",
+		},
+		{
+			name:   "never beats force color",
+			color:  "never",
+			env:    map[string]string{"FORCE_COLOR": "1"},
+			stderr: "<stdin>:1:1-2: unexpected character '$'\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Cleanup(func() { hue.Enabled(false) })
+
+			t.Setenv("FORCE_COLOR", "")
+			t.Setenv("NO_COLOR", "")
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			// A real file so auto detection has a file descriptor to check
+			stderr, err := os.Create(filepath.Join(t.TempDir(), "stderr"))
+			test.Ok(t, err)
+			defer stderr.Close()
+
+			app := cli.New(strings.NewReader("$"), &bytes.Buffer{}, stderr)
+
+			err = app.Run([]string{"--color", tt.color, "tokens", "-"})
+			test.Err(t, err)
+
+			got, err := os.ReadFile(stderr.Name())
+			test.Ok(t, err)
+			test.Equal(t, string(got), tt.stderr)
+		})
+	}
+}
+
+func TestUsage(t *testing.T) {
+	tests := []struct {
+		name    string   // Name of the test case
+		args    []string // Arguments to pass to Run
+		stdout  string   // Expected prefix of stdout, empty means stdout must be empty
+		stderr  string   // Expected prefix of stderr, empty means stderr must be empty
+		wantErr bool     // Whether Run should return an error
+	}{
+		{
+			name:   "root help",
+			args:   []string{"--help"},
+			stdout: "An implementation of the Lox language",
+		},
+		{
+			name:    "root bad flag",
+			args:    []string{"--bogus"},
+			stderr:  "An implementation of the Lox language",
+			wantErr: true,
+		},
+		{
+			name:   "command help",
+			args:   []string{"tokens", "--help"},
+			stdout: "Print the token stream of a Lox file",
+		},
+		{
+			name:    "command bad flag",
+			args:    []string{"tokens", "--bogus"},
+			stderr:  "Print the token stream of a Lox file",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout := &bytes.Buffer{}
+			stderr := &bytes.Buffer{}
+
+			err := cli.New(strings.NewReader(""), stdout, stderr).Run(tt.args)
+			test.WantErr(t, err, tt.wantErr)
+
+			if tt.stdout == "" {
+				test.Equal(t, stdout.String(), "")
+			} else {
+				test.True(t, strings.HasPrefix(stdout.String(), tt.stdout), test.Context("stdout was %q", stdout.String()))
+			}
+
+			if tt.stderr == "" {
+				test.Equal(t, stderr.String(), "")
+			} else {
+				test.True(t, strings.HasPrefix(stderr.String(), tt.stderr), test.Context("stderr was %q", stderr.String()))
+			}
+
+			// The error is reported once by the caller, not by the flag package too
+			test.False(t, strings.Contains(stderr.String(), "flag provided but not defined"))
+		})
+	}
+}
+
+func TestVersion(t *testing.T) {
+	t.Run("text", func(t *testing.T) {
+		stdout := &bytes.Buffer{}
+		err := cli.New(strings.NewReader(""), stdout, &bytes.Buffer{}).Run([]string{"version"})
+		test.Ok(t, err)
+
+		test.True(t, strings.HasPrefix(stdout.String(), "Version:"), test.Context("stdout was %q", stdout.String()))
+		test.True(t, strings.Contains(stdout.String(), "Go:       go"), test.Context("stdout was %q", stdout.String()))
+	})
+
+	t.Run("json", func(t *testing.T) {
+		stdout := &bytes.Buffer{}
+		err := cli.New(strings.NewReader(""), stdout, &bytes.Buffer{}).Run([]string{"version", "--json"})
+		test.Ok(t, err)
+
+		var got struct {
+			Version    string   `json:"version"`
+			Commit     string   `json:"commit"`
+			Date       string   `json:"date"`
+			Go         string   `json:"go"`
+			Extensions []string `json:"extensions"`
+		}
+		test.Ok(t, json.Unmarshal(stdout.Bytes(), &got))
+
+		test.NotEqual(t, got.Version, "")
+		test.Equal(t, got.Go, runtime.Version())
+		test.True(t, got.Extensions != nil, test.Context("extensions should be [] not null"))
+	})
+
+	t.Run("flag", func(t *testing.T) {
+		stdout := &bytes.Buffer{}
+		err := cli.New(strings.NewReader(""), stdout, &bytes.Buffer{}).Run([]string{"--version"})
+		test.Ok(t, err)
+
+		test.True(t, strings.HasPrefix(stdout.String(), "glox "), test.Context("stdout was %q", stdout.String()))
+	})
+
+	t.Run("args", func(t *testing.T) {
+		err := cli.New(strings.NewReader(""), &bytes.Buffer{}, &bytes.Buffer{}).Run([]string{"version", "extra"})
+		test.Err(t, err)
+	})
+}
+
+func TestHighlight(t *testing.T) {
+	tests := []struct {
+		env     map[string]string // Environment variables to set
+		name    string            // Name of the test case
+		src     string            // Lox source to highlight
+		args    []string          // Arguments to glox, "-" is appended
+		stdout  string            // Expected stdout
+		wantErr bool              // Whether Run should return an error
+	}{
+		{
+			name:   "ansi",
+			src:    "var x = \"hi\"; // greet\nprint 1.5;",
+			args:   []string{"--color", "always", "highlight"},
+			stdout: "\x1b[1;35mvar\x1b[0m x = \x1b[32m\"hi\"\x1b[0m; \x1b[90m// greet\x1b[0m\n\x1b[1;35mprint\x1b[0m \x1b[36m1.5\x1b[0m;",
+		},
+		{
+			name:   "ansi color never",
+			src:    "var x = \"hi\"; // greet",
+			args:   []string{"--color", "never", "highlight"},
+			stdout: "var x = \"hi\"; // greet",
+		},
+		{
+			name:   "ansi no color",
+			src:    "var x = 1;",
+			args:   []string{"highlight", "--format", "ansi"},
+			env:    map[string]string{"NO_COLOR": "1"},
+			stdout: "var x = 1;",
+		},
+		{
+			name:   "html",
+			src:    "if (a < b) {}\n// done\n",
+			args:   []string{"highlight", "--format", "html"},
+			stdout: "<pre class=\"lox\"><code><span class=\"lox-keyword\">if</span> (a &lt; b) {}\n<span class=\"lox-comment\">// done</span>\n</code></pre>\n",
+		},
+		{
+			name:    "syntax errors",
+			src:     "a $",
+			args:    []string{"highlight", "--format", "html"},
+			stdout:  "<pre class=\"lox\"><code>a <span class=\"lox-error\">$</span></code></pre>\n",
+			wantErr: true,
+		},
+		{
+			name:    "bad format",
+			src:     "a",
+			args:    []string{"highlight", "--format", "rtf"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Cleanup(func() { hue.Enabled(false) })
+
+			// Don't inherit colour settings from the shell running the tests
+			t.Setenv("FORCE_COLOR", "")
+			t.Setenv("NO_COLOR", "")
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			stdout := &bytes.Buffer{}
+			args := append(tt.args, "-")
+
+			err := cli.New(strings.NewReader(tt.src), stdout, &bytes.Buffer{}).Run(args)
+			test.WantErr(t, err, tt.wantErr)
+
+			test.Equal(t, stdout.String(), tt.stdout)
+		})
+	}
+}
+
+func TestGen(t *testing.T) {
+	run := func(args ...string) (string, error) {
+		stdout := &bytes.Buffer{}
+		err := cli.New(strings.NewReader(""), stdout, &bytes.Buffer{}).Run(append([]string{"gen"}, args...))
+		return stdout.String(), err
+	}
+
+	first, err := run("--seed", "42", "--statements", "5")
+	test.Ok(t, err)
+	test.True(t, strings.HasPrefix(first, "// seed: 42\n"), test.Context("stdout was %q", first))
+
+	second, err := run("--seed", "42", "--statements", "5")
+	test.Ok(t, err)
+	test.Equal(t, second, first)
+
+	random, err := run("--statements", "1")
+	test.Ok(t, err)
+	test.True(t, strings.HasPrefix(random, "// seed: "), test.Context("stdout was %q", random))
+
+	_, err = run("--depth", "0")
+	test.Err(t, err)
+
+	_, err = run("--statements", "-1")
+	test.Err(t, err)
+
+	_, err = run("extra")
+	test.Err(t, err)
+}
+
+func TestCompletion(t *testing.T) {
+	for _, shell := range []string{"bash", "zsh", "fish"} {
+		t.Run(shell, func(t *testing.T) {
+			stdout := &bytes.Buffer{}
+			err := cli.New(strings.NewReader(""), stdout, &bytes.Buffer{}).Run([]string{"completion", shell})
+			test.Ok(t, err)
+
+			script := stdout.String()
+			for _, want := range []string{"completion", "gen", "highlight", "tokens", "version", "color", "format", "json", ".lox"} {
+				test.True(t, strings.Contains(script, want), test.Context("%s script missing %q", shell, want))
+			}
+
+			// -v and -vv are single dash flags, as in the usage
+			for _, notWant := range []string{"--v ", "--v[", "--vv", "-l v ", "-l vv"} {
+				test.False(t, strings.Contains(script, notWant), test.Context("%s script contains %q", shell, notWant))
+			}
+
+			// If the shell is installed, make sure the script at least parses
+			if path, err := exec.LookPath(shell); err == nil {
+				file := filepath.Join(t.TempDir(), "completion."+shell)
+				test.Ok(t, os.WriteFile(file, stdout.Bytes(), 0o644))
+
+				out, err := exec.Command(path, "-n", file).CombinedOutput()
+				test.Ok(t, err, test.Context("%s -n failed: %s", shell, out))
+			}
+		})
+	}
+
+	t.Run("matches commands", func(t *testing.T) {
+		stdout := &bytes.Buffer{}
+		err := cli.New(strings.NewReader(""), stdout, &bytes.Buffer{}).Run([]string{"--help"})
+		test.Ok(t, err)
+
+		// The commands listed in the top level help
+		_, list, ok := strings.Cut(stdout.String(), "Commands:\n")
+		test.True(t, ok, test.Context("no commands in help: %q", stdout.String()))
+		list, _, _ = strings.Cut(list, "\n\n")
+
+		script := &bytes.Buffer{}
+		err = cli.New(strings.NewReader(""), script, &bytes.Buffer{}).Run([]string{"completion", "bash"})
+		test.Ok(t, err)
+
+		for line := range strings.Lines(list) {
+			command := strings.Fields(line)[0]
+
+			// Each command's arm of the case statement must offer exactly the flags the command accepts
+			_, arm, ok := strings.Cut(script.String(), "        "+command+")\n")
+			test.True(t, ok, test.Context("command %q missing from completion", command))
+			arm, _, _ = strings.Cut(arm, "\n            ;;\n")
+
+			help := &bytes.Buffer{}
+			err := cli.New(strings.NewReader(""), help, &bytes.Buffer{}).Run([]string{command, "--help"})
+			test.Ok(t, err, test.Context("%s --help", command))
+
+			for line := range strings.Lines(help.String()) {
+				if name, ok := strings.CutPrefix(line, "  -"); ok {
+					name = strings.Fields(name)[0]
+					test.True(t, strings.Contains(arm, "--"+name), test.Context("%s flag --%s missing from completion", command, name))
+				}
+			}
+		}
+	})
+
+	t.Run("unknown shell", func(t *testing.T) {
+		err := cli.New(strings.NewReader(""), &bytes.Buffer{}, &bytes.Buffer{}).Run([]string{"completion", "powershell"})
+		test.Err(t, err)
+	})
+
+	t.Run("no shell", func(t *testing.T) {
+		err := cli.New(strings.NewReader(""), &bytes.Buffer{}, &bytes.Buffer{}).Run([]string{"completion"})
+		test.Err(t, err)
+	})
+}
+
+func TestVerbose(t *testing.T) {
+	tests := []struct {
+		name    string   // Name of the test case
+		flags   []string // Verbosity flags to pass
+		want    []string // Log messages expected on stderr
+		notWant []string // Log messages that must not be on stderr
+	}{
+		{
+			name:    "quiet",
+			flags:   nil,
+			notWant: []string{"msg=lexed", "msg=\"read source\""},
+		},
+		{
+			name:    "info",
+			flags:   []string{"-v"},
+			want:    []string{"level=INFO msg=lexed command=tokens file=<stdin> tokens=1 errors=0"},
+			notWant: []string{"level=DEBUG"},
+		},
+		{
+			name:  "debug",
+			flags: []string{"-vv"},
+			want:  []string{"level=DEBUG msg=\"read source\" command=tokens file=<stdin> bytes=1", "level=INFO msg=lexed"},
+		},
+		{
+			name:  "repeated",
+			flags: []string{"-v", "-v"},
+			want:  []string{"level=DEBUG msg=lexing"},
+		},
+		{
+			name:    "explicitly off",
+			flags:   []string{"-v=false", "-vv=false"},
+			notWant: []string{"msg=lexed", "msg=\"read source\""},
+		},
+		{
+			name:    "off then on",
+			flags:   []string{"-v=false", "-v=true"},
+			want:    []string{"level=INFO msg=lexed"},
+			notWant: []string{"level=DEBUG"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stderr := &bytes.Buffer{}
+
+			args := append(tt.flags, "tokens", "-")
+			err := cli.New(strings.NewReader("x"), &bytes.Buffer{}, stderr).Run(args)
+			test.Ok(t, err)
+
+			for _, want := range tt.want {
+				test.True(t, strings.Contains(stderr.String(), want), test.Context("stderr was %q", stderr.String()))
+			}
+			for _, notWant := range tt.notWant {
+				test.False(t, strings.Contains(stderr.String(), notWant), test.Context("stderr was %q", stderr.String()))
+			}
+		})
+	}
+}
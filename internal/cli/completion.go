@@ -0,0 +1,402 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// completionUsage is the help text for 'glox completion'.
+const completionUsage = `Print a shell completion script for glox
+
+Usage: glox completion <bash|zsh|fish>
+
+To load completions in the current shell:
+
+  bash: source <(glox completion bash)
+  zsh:  source <(glox completion zsh)
+  fish: glox completion fish | source
+
+Or to install them permanently:
+
+  bash: glox completion bash > /etc/bash_completion.d/glox
+  zsh:  glox completion zsh > "${fpath[1]}/_glox"
+  fish: glox completion fish > ~/.config/fish/completions/glox.fish
+
+Flags:
+`
+
+// shells are the shells 'glox completion' can generate a script for.
+var shells = []string{"bash", "zsh", "fish"}
+
+// flagSpec describes a single flag for the purposes of shell completion.
+type flagSpec struct {
+	name   string   // Name of the flag, without leading dashes
+	help   string   // Short description of the flag
+	values []string // Fixed set of values the flag accepts, if any
+	arg    bool     // Whether the flag takes a value
+}
+
+// commandSpec describes a single glox command for the purposes of shell completion.
+type commandSpec struct {
+	flags  func() *flag.FlagSet // Returns the command's flags, nil if it has none
+	values map[string][]string  // Fixed set of values accepted by any of the flags, by flag name
+	name   string               // Name of the command
+	help   string               // Short description of the command
+	args   []string             // Fixed set of positional arguments the command accepts, if any
+	files  bool                 // Whether the command takes a .lox file argument
+}
+
+// commands is every glox command. The flags for each come from the same [flag.FlagSet]
+// the command itself parses so they can't get out of sync.
+var commands = []commandSpec{
+	{
+		name:  "completion",
+		help:  "Print a shell completion script for glox",
+		flags: completionFlags,
+		args:  shells,
+	},
+	{
+		name: "gen",
+		help: "Generate a random, syntactically valid Lox program",
+		flags: func() *flag.FlagSet {
+			return genFlags(new(uint64), new(int), new(int))
+		},
+	},
+	{
+		name: "help",
+		help: "Show help and exit",
+	},
+	{
+		name:   "highlight",
+		help:   "Print a Lox file with syntax highlighting",
+		flags:  func() *flag.FlagSet { return highlightFlags(new(string)) },
+		values: map[string][]string{"format": highlightFormats},
+		files:  true,
+	},
+	{
+		name:   "tokens",
+		help:   "Print the token stream of a Lox file",
+		flags:  func() *flag.FlagSet { return tokensFlags(new(string)) },
+		values: map[string][]string{"format": tokensFormats},
+		files:  true,
+	},
+	{
+		name:  "version",
+		help:  "Print glox version and build information",
+		flags: func() *flag.FlagSet { return versionFlags(new(bool)) },
+	},
+}
+
+// flagSpecs returns the completion specs for every flag in flags, plus -help
+// which the flag package handles itself. values gives the fixed set of values
+// accepted by a flag, by name.
+func flagSpecs(flags *flag.FlagSet, values map[string][]string) []flagSpec {
+	var specs []flagSpec
+	if flags != nil {
+		flags.VisitAll(func(f *flag.Flag) {
+			boolFlag, ok := f.Value.(interface{ IsBoolFlag() bool })
+			specs = append(specs, flagSpec{
+				name:   f.Name,
+				help:   f.Usage,
+				values: values[f.Name],
+				arg:    !ok || !boolFlag.IsBoolFlag(),
+			})
+		})
+	}
+
+	return append(specs, flagSpec{name: "help", help: "Show help and exit"})
+}
+
+// short reports whether the flag is written with a single dash, as in the usage text.
+// These are single letter flags and the repeated letter forms like -vv.
+func (f flagSpec) short() bool {
+	return f.name != "" && strings.Trim(f.name, f.name[:1]) == ""
+}
+
+// flag returns the flag as it should be typed, with its leading dash(es).
+func (f flagSpec) flag() string {
+	if f.short() {
+		return "-" + f.name
+	}
+	return "--" + f.name
+}
+
+// quote returns s single quoted for bash, zsh and fish. Any single quote in s closes
+// the quoted string, is inserted backslash escaped and then reopens it, which all three
+// shells understand.
+func quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// globalFlagSpecs returns the completion specs for the flags accepted by glox itself.
+func globalFlagSpecs() []flagSpec {
+	color := ""
+	flags := rootFlags(&color, new(bool), new(verbosity))
+	return flagSpecs(flags, map[string][]string{"color": colorModes})
+}
+
+// flagSpecs returns the completion specs for the command's flags.
+func (c commandSpec) flagSpecs() []flagSpec {
+	var flags *flag.FlagSet
+	if c.flags != nil {
+		flags = c.flags()
+	}
+	return flagSpecs(flags, c.values)
+}
+
+// completionFlags returns the flags accepted by 'glox completion'.
+func completionFlags() *flag.FlagSet {
+	flags := flag.NewFlagSet("completion", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), completionUsage)
+		flags.PrintDefaults()
+	}
+
+	return flags
+}
+
+// completion implements the 'glox completion' command.
+func (a App) completion(args []string) error {
+	flags := completionFlags()
+
+	if done, err := a.parseFlags(flags, args); done {
+		return err
+	}
+
+	if flags.NArg() != 1 {
+		return fmt.Errorf("completion expects exactly 1 shell argument, got %d", flags.NArg())
+	}
+
+	var script string
+	switch shell := flags.Arg(0); shell {
+	case "bash":
+		script = bashCompletion()
+	case "zsh":
+		script = zshCompletion()
+	case "fish":
+		script = fishCompletion()
+	default:
+		return fmt.Errorf("unsupported shell %q, expected one of bash, zsh or fish", shell)
+	}
+
+	_, err := fmt.Fprint(a.stdout, script)
+	return err
+}
+
+// bashCompletion returns the completion script for bash.
+func bashCompletion() string {
+	b := &strings.Builder{}
+
+	b.WriteString(`# bash completion for glox, generated by 'glox completion bash'
+
+_glox() {
+    local cur="${COMP_WORDS[COMP_CWORD]}" prev="${COMP_WORDS[COMP_CWORD-1]}"
+    local cmd="" i
+
+    for ((i = 1; i < COMP_CWORD; i++)); do
+        case "${COMP_WORDS[i]}" in
+`)
+	for _, f := range globalFlagSpecs() {
+		if f.arg {
+			fmt.Fprintf(b, "            -%[1]s|--%[1]s) ((i++)) ;;\n", f.name)
+		}
+	}
+	b.WriteString(`            -*) ;;
+            *) cmd="${COMP_WORDS[i]}"; break ;;
+        esac
+    done
+
+    case "$cmd" in
+`)
+
+	names := make([]string, 0, len(commands))
+	for _, cmd := range commands {
+		names = append(names, cmd.name)
+	}
+	bashScope(b, `""`, globalFlagSpecs(), `compgen -W "`+strings.Join(names, " ")+`" -- "$cur"`)
+
+	for _, cmd := range commands {
+		var words string
+		switch {
+		case cmd.files:
+			words = `compgen -f -X '!*.lox' -- "$cur"; compgen -d -- "$cur"`
+		case len(cmd.args) != 0:
+			words = `compgen -W "` + strings.Join(cmd.args, " ") + `" -- "$cur"`
+		}
+		bashScope(b, cmd.name, cmd.flagSpecs(), words)
+	}
+
+	b.WriteString(`    esac
+}
+
+complete -o filenames -F _glox glox
+`)
+
+	return b.String()
+}
+
+// bashScope writes the bash case arm completing the flags and arguments for a single command, words
+// is the command producing completions for arguments and may be empty if there are none.
+func bashScope(b *strings.Builder, name string, flags []flagSpec, words string) {
+	fmt.Fprintf(b, "        %s)\n", name)
+
+	b.WriteString("            case \"$prev\" in\n")
+	for _, f := range flags {
+		switch {
+		case len(f.values) != 0:
+			fmt.Fprintf(
+				b,
+				"                -%[1]s|--%[1]s) COMPREPLY=($(compgen -W %[2]q -- \"$cur\")); return ;;\n",
+				f.name,
+				strings.Join(f.values, " "),
+			)
+		case f.arg:
+			fmt.Fprintf(b, "                -%[1]s|--%[1]s) return ;;\n", f.name)
+		}
+	}
+	b.WriteString("            esac\n")
+
+	flagNames := make([]string, 0, len(flags))
+	for _, f := range flags {
+		flagNames = append(flagNames, f.flag())
+	}
+
+	b.WriteString("            if [[ \"$cur\" == -* ]]; then\n")
+	fmt.Fprintf(b, "                COMPREPLY=($(compgen -W %q -- \"$cur\"))\n", strings.Join(flagNames, " "))
+	if words != "" {
+		b.WriteString("            else\n")
+		fmt.Fprintf(b, "                COMPREPLY=($(%s))\n", words)
+	}
+	b.WriteString("            fi\n")
+	b.WriteString("            ;;\n")
+}
+
+// zshCompletion returns the completion script for zsh.
+func zshCompletion() string {
+	b := &strings.Builder{}
+
+	b.WriteString(`#compdef glox
+# zsh completion for glox, generated by 'glox completion zsh'
+
+_glox() {
+    local line state
+
+    _arguments -C \
+`)
+	for _, f := range globalFlagSpecs() {
+		fmt.Fprintf(b, "        %s \\\n", zshFlag(f))
+	}
+	b.WriteString(`        '1:command:->command' \
+        '*::arg:->args'
+
+    case $state in
+        command)
+            local -a commands=(
+`)
+	for _, cmd := range commands {
+		fmt.Fprintf(b, "                %s\n", quote(cmd.name+":"+cmd.help))
+	}
+	b.WriteString(`            )
+            _describe 'command' commands
+            ;;
+        args)
+            case $line[1] in
+`)
+	for _, cmd := range commands {
+		fmt.Fprintf(b, "                %s)\n", cmd.name)
+		specs := []string{}
+		for _, f := range cmd.flagSpecs() {
+			specs = append(specs, zshFlag(f))
+		}
+		switch {
+		case cmd.files:
+			specs = append(specs, `'1:file:_files -g "*.lox"'`)
+		case len(cmd.args) != 0:
+			specs = append(specs, "'1:"+cmd.name+":("+strings.Join(cmd.args, " ")+")'")
+		}
+		b.WriteString("                    _arguments \\\n                        ")
+		b.WriteString(strings.Join(specs, " \\\n                        "))
+		b.WriteString("\n                    ;;\n")
+	}
+	b.WriteString(`            esac
+            ;;
+    esac
+}
+
+if [ "$funcstack[1]" = "_glox" ]; then
+    _glox "$@"
+else
+    compdef _glox glox
+fi
+`)
+
+	return b.String()
+}
+
+// zshFlag returns the _arguments spec for a single flag, quoted.
+func zshFlag(f flagSpec) string {
+	// Inside the [...] description _arguments needs ], : and backslash escaping
+	help := strings.NewReplacer(`\`, `\\`, "]", `\]`, ":", `\:`).Replace(f.help)
+
+	spec := f.flag() + "[" + help + "]"
+	switch {
+	case len(f.values) != 0:
+		spec += ":" + f.name + ":(" + strings.Join(f.values, " ") + ")"
+	case f.arg:
+		spec += ":" + f.name + ":"
+	}
+	return quote(spec)
+}
+
+// fishCompletion returns the completion script for fish.
+func fishCompletion() string {
+	b := &strings.Builder{}
+
+	b.WriteString(`# fish completion for glox, generated by 'glox completion fish'
+
+complete -c glox -f
+`)
+	for _, f := range globalFlagSpecs() {
+		b.WriteString(fishFlag("__fish_use_subcommand", f))
+	}
+	for _, cmd := range commands {
+		fmt.Fprintf(b, "complete -c glox -n __fish_use_subcommand -a %s -d %s\n", cmd.name, quote(cmd.help))
+	}
+
+	for _, cmd := range commands {
+		condition := "'__fish_seen_subcommand_from " + cmd.name + "'"
+		for _, f := range cmd.flagSpecs() {
+			b.WriteString(fishFlag(condition, f))
+		}
+		switch {
+		case cmd.files:
+			fmt.Fprintf(b, "complete -c glox -n %s -k -a '(__fish_complete_suffix .lox)'\n", condition)
+		case len(cmd.args) != 0:
+			fmt.Fprintf(b, "complete -c glox -n %s -a '%s'\n", condition, strings.Join(cmd.args, " "))
+		}
+	}
+
+	return b.String()
+}
+
+// fishFlag returns the complete command for a single flag, offered when condition holds.
+func fishFlag(condition string, f flagSpec) string {
+	line := "complete -c glox -n " + condition
+	switch {
+	case len(f.name) == 1:
+		line += " -s " + f.name
+	case f.short():
+		line += " -o " + f.name
+	default:
+		line += " -l " + f.name
+	}
+
+	switch {
+	case len(f.values) != 0:
+		line += " -x -a '" + strings.Join(f.values, " ") + "'"
+	case f.arg:
+		line += " -x"
+	}
+	return line + " -d " + quote(f.help) + "\n"
+}
@@ -0,0 +1,82 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"math/rand/v2"
+
+	"github.com/FollowTheProcess/glox/internal/syntax/gen"
+)
+
+// genUsage is the help text for 'glox gen'.
+const genUsage = `Generate a random, syntactically valid Lox program
+
+Usage: glox gen [flags]
+
+The program is only well formed, not meaningful, and is intended as input for
+fuzzing, stress testing and benchmarks. The seed is written as a comment on the
+first line so any program can be generated again.
+
+Flags:
+`
+
+// genFlags returns the flags accepted by 'glox gen'.
+func genFlags(seed *uint64, statements, depth *int) *flag.FlagSet {
+	flags := flag.NewFlagSet("gen", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), genUsage)
+		flags.PrintDefaults()
+	}
+
+	flags.Uint64Var(seed, "seed", 0, "Seed for the generator, random if not set")
+	flags.IntVar(statements, "statements", 20, "Number of top level declarations to generate")
+	flags.IntVar(depth, "depth", 5, "Maximum nesting depth of statements and expressions")
+
+	return flags
+}
+
+// gen implements the 'glox gen' command.
+func (a App) gen(args []string) error {
+	var (
+		seed              uint64
+		statements, depth int
+	)
+	flags := genFlags(&seed, &statements, &depth)
+
+	if done, err := a.parseFlags(flags, args); done {
+		return err
+	}
+
+	if flags.NArg() != 0 {
+		return fmt.Errorf("gen takes no arguments, got %d", flags.NArg())
+	}
+
+	if statements < 0 {
+		return fmt.Errorf("invalid --statements %d, must not be negative", statements)
+	}
+
+	if depth < 1 {
+		return fmt.Errorf("invalid --depth %d, must be at least 1", depth)
+	}
+
+	seedSet := false
+	flags.Visit(func(f *flag.Flag) {
+		if f.Name == "seed" {
+			seedSet = true
+		}
+	})
+
+	if !seedSet {
+		seed = rand.Uint64() //nolint:gosec // Only used to pick a seed
+	}
+
+	generator := gen.New(gen.Config{
+		Seed:       seed,
+		Statements: statements,
+		MaxDepth:   depth,
+	})
+
+	fmt.Fprintf(a.stdout, "// seed: %d\n%s", seed, generator.Program())
+
+	return nil
+}
@@ -0,0 +1,222 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/FollowTheProcess/glox/internal/syntax/token"
+	"github.com/FollowTheProcess/hue"
+)
+
+// highlightUsage is the help text for 'glox highlight'.
+const highlightUsage = `Print a Lox file with syntax highlighting
+
+Usage: glox highlight [flags] <file>
+
+Pass "-" as the file to read the source from stdin.
+
+The ansi format follows --color, so pass --color always to keep the colour
+when piping into a pager e.g. 'glox --color always highlight file.lox | less -R'.
+
+Flags:
+`
+
+// class is the highlighting category of a span of source text.
+type class int
+
+const (
+	plain   class = iota // Identifiers, punctuation and whitespace, not highlighted
+	keyword              // Reserved words
+	str                  // String literals
+	num                  // Number literals
+	comment              // Line comments
+	invalid              // Anything the lexer reported an error for
+)
+
+// highlighter writes spans of classified source text in a particular output format.
+type highlighter func(b *strings.Builder, text string, c class)
+
+// highlightFormats are the accepted values of 'glox highlight --format'.
+var highlightFormats = []string{"ansi", "html"}
+
+// highlightFlags returns the flags accepted by 'glox highlight'.
+func highlightFlags(format *string) *flag.FlagSet {
+	flags := flag.NewFlagSet("highlight", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), highlightUsage)
+		flags.PrintDefaults()
+	}
+
+	flags.StringVar(format, "format", "ansi", "Output format, one of ansi or html")
+
+	return flags
+}
+
+// highlight implements the 'glox highlight' command.
+func (a App) highlight(args []string) error {
+	var format string
+	flags := highlightFlags(&format)
+
+	if done, err := a.parseFlags(flags, args); done {
+		return err
+	}
+
+	if flags.NArg() != 1 {
+		return fmt.Errorf("highlight expects exactly 1 file argument, got %d", flags.NArg())
+	}
+
+	var span highlighter
+	switch format {
+	case "ansi":
+		span = ansiSpan
+	case "html":
+		span = htmlSpan
+	default:
+		return fmt.Errorf("invalid --format %q, expected one of ansi or html", format)
+	}
+
+	name, src, err := a.readSource(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	lexemes, nErrors := a.scan(name, src)
+
+	// Diagnostics above went to stderr, the highlighted source goes to stdout which
+	// may or may not be a terminal, put it back afterwards for any errors
+	hue.Enabled(a.useColor(a.stdout))
+	defer hue.Enabled(a.useColor(a.stderr))
+
+	b := &strings.Builder{}
+	if format == "html" {
+		b.WriteString(`<pre class="lox"><code>`)
+	}
+
+	// The lexer discards whitespace and comments, so everything between
+	// two tokens is written back out verbatim with only comments highlighted
+	offset := 0
+	for _, lex := range lexemes {
+		writeGap(b, src[offset:lex.Start], span)
+		span(b, lex.Text, classify(lex))
+		offset = lex.End
+	}
+	writeGap(b, src[offset:], span)
+
+	if format == "html" {
+		b.WriteString("</code></pre>\n")
+	}
+
+	if _, err := fmt.Fprint(a.stdout, b.String()); err != nil {
+		return err
+	}
+
+	if nErrors > 0 {
+		return fmt.Errorf("%s: found %d syntax error(s)", name, nErrors)
+	}
+
+	return nil
+}
+
+// ansiStyle returns the style used by the ansi format for c, or false if c is not highlighted.
+func (c class) ansiStyle() (hue.Style, bool) {
+	switch c {
+	case keyword:
+		return hue.Magenta | hue.Bold, true
+	case str:
+		return hue.Green, true
+	case num:
+		return hue.Cyan, true
+	case comment:
+		return hue.BrightBlack, true
+	case invalid:
+		return hue.Red | hue.Underline, true
+	case plain:
+		return 0, false
+	default:
+		return 0, false
+	}
+}
+
+// htmlClass returns the CSS class used by the html format for c, or false if c is not highlighted.
+func (c class) htmlClass() (string, bool) {
+	switch c {
+	case keyword:
+		return "lox-keyword", true
+	case str:
+		return "lox-string", true
+	case num:
+		return "lox-number", true
+	case comment:
+		return "lox-comment", true
+	case invalid:
+		return "lox-error", true
+	case plain:
+		return "", false
+	default:
+		return "", false
+	}
+}
+
+// classify returns the highlighting class of a single lexeme.
+func classify(lex lexeme) class {
+	switch lex.tok.Kind {
+	case token.String:
+		return str
+	case token.Number:
+		return num
+	case token.Error:
+		return invalid
+	case token.And, token.Class, token.Else, token.False, token.Fun, token.For, token.If, token.Nil,
+		token.Or, token.Print, token.Return, token.Super, token.This, token.True, token.Var, token.While:
+		return keyword
+	default:
+		return plain
+	}
+}
+
+// writeGap writes the text between two tokens, which may only contain whitespace
+// and line comments.
+func writeGap(b *strings.Builder, gap string, span highlighter) {
+	for gap != "" {
+		start := strings.Index(gap, "//")
+		if start == -1 {
+			span(b, gap, plain)
+			return
+		}
+
+		end := strings.IndexByte(gap[start:], '\n')
+		if end == -1 {
+			end = len(gap)
+		} else {
+			end += start
+		}
+
+		span(b, gap[:start], plain)
+		span(b, gap[start:end], comment)
+		gap = gap[end:]
+	}
+}
+
+// ansiSpan writes text styled for its class, if colour is enabled.
+func ansiSpan(b *strings.Builder, text string, c class) {
+	style, ok := c.ansiStyle()
+	if !ok || text == "" {
+		b.WriteString(text)
+		return
+	}
+
+	b.WriteString(style.Text(text))
+}
+
+// htmlSpan writes text, HTML escaped, wrapped in a span for its class.
+func htmlSpan(b *strings.Builder, text string, c class) {
+	cssClass, ok := c.htmlClass()
+	if !ok || text == "" {
+		b.WriteString(html.EscapeString(text))
+		return
+	}
+
+	fmt.Fprintf(b, `<span class="%s">%s</span>`, cssClass, html.EscapeString(text))
+}
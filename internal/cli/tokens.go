@@ -0,0 +1,139 @@
+package cli
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"slices"
+	"text/tabwriter"
+	"time"
+
+	"github.com/FollowTheProcess/glox/internal/syntax"
+	"github.com/FollowTheProcess/glox/internal/syntax/lexer"
+	"github.com/FollowTheProcess/glox/internal/syntax/token"
+)
+
+// tokensUsage is the help text for 'glox tokens'.
+const tokensUsage = `Print the token stream of a Lox file
+
+Usage: glox tokens [flags] <file>
+
+Pass "-" as the file to read the source from stdin.
+
+Flags:
+`
+
+// lexeme is a single token in the output of 'glox tokens', along with the
+// source text it was scanned from and its human readable position.
+type lexeme struct {
+	Kind   string      `json:"kind"`   // The token kind
+	Text   string      `json:"lexeme"` // The source text of the token
+	Line   int         `json:"line"`   // Line number the token starts on (1 indexed)
+	Column int         `json:"column"` // Column number (1 indexed)
+	Start  int         `json:"start"`  // Byte offset of the start of the token
+	End    int         `json:"end"`    // Byte offset of the end of the token
+	tok    token.Token // The original token
+}
+
+// tokensFormats are the accepted values of 'glox tokens --format'.
+var tokensFormats = []string{"table", "json", "raw"}
+
+// tokensFlags returns the flags accepted by 'glox tokens'.
+func tokensFlags(format *string) *flag.FlagSet {
+	flags := flag.NewFlagSet("tokens", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), tokensUsage)
+		flags.PrintDefaults()
+	}
+
+	flags.StringVar(format, "format", "table", "Output format, one of table, json or raw")
+
+	return flags
+}
+
+// tokens implements the 'glox tokens' command.
+func (a App) tokens(args []string) error {
+	var format string
+	flags := tokensFlags(&format)
+
+	if done, err := a.parseFlags(flags, args); done {
+		return err
+	}
+
+	if flags.NArg() != 1 {
+		return fmt.Errorf("tokens expects exactly 1 file argument, got %d", flags.NArg())
+	}
+
+	if !slices.Contains(tokensFormats, format) {
+		return fmt.Errorf("invalid --format %q, expected one of table, json or raw", format)
+	}
+
+	name, src, err := a.readSource(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	lexemes, nErrors := a.scan(name, src)
+
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(a.stdout)
+		encoder.SetIndent("", "  ")
+		encoder.SetEscapeHTML(false)
+		if err := encoder.Encode(lexemes); err != nil {
+			return err
+		}
+	case "raw":
+		for _, lex := range lexemes {
+			fmt.Fprintln(a.stdout, lex.tok)
+		}
+	default:
+		tw := tabwriter.NewWriter(a.stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "POSITION\tKIND\tLEXEME")
+		for _, lex := range lexemes {
+			fmt.Fprintf(tw, "%d:%d\t%s\t%q\n", lex.Line, lex.Column, lex.Kind, lex.Text)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+
+	if nErrors > 0 {
+		return fmt.Errorf("%s: found %d syntax error(s)", name, nErrors)
+	}
+
+	return nil
+}
+
+// scan lexes src until EOF, returning every token (excluding EOF) along with the
+// number of syntax errors encountered. Errors are reported to stderr as they occur.
+func (a App) scan(name, src string) (lexemes []lexeme, nErrors int) {
+	handler := func(pos syntax.Position, msg string) {
+		nErrors++
+		a.diagnostic(pos, msg)
+	}
+
+	lex := lexer.New(name, src, handler)
+
+	a.logger.Debug("lexing", "file", name)
+	start := time.Now()
+
+	file := lex.File()
+	lexemes = []lexeme{}
+	for tok := lex.NextToken(); tok.Kind != token.EOF; tok = lex.NextToken() {
+		line, column := file.LineCol(tok.Start)
+		lexemes = append(lexemes, lexeme{
+			Kind:   tok.Kind.String(),
+			Text:   src[tok.Start:tok.End],
+			Line:   line,
+			Column: column,
+			Start:  tok.Start,
+			End:    tok.End,
+			tok:    tok,
+		})
+	}
+
+	a.logger.Info("lexed", "file", name, "tokens", len(lexemes), "errors", nErrors, "duration", time.Since(start))
+
+	return lexemes, nErrors
+}
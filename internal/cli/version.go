@@ -0,0 +1,119 @@
+package cli
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"text/tabwriter"
+)
+
+// Build information, set at link time by goreleaser with -ldflags "-X ...".
+var (
+	version = "dev" // The released version of glox, v prefixed like the git tag e.g. v1.2.3
+	commit  = ""    // The git commit glox was built from
+	date    = ""    // The time glox was built, RFC3339 formatted
+)
+
+// extensions are the language extensions on top of book Lox supported by this build.
+var extensions = []string{}
+
+// versionUsage is the help text for 'glox version'.
+const versionUsage = `Print glox version and build information
+
+Usage: glox version [flags]
+
+Flags:
+`
+
+// buildInfo is the output of 'glox version'.
+type buildInfo struct {
+	Version    string   `json:"version"`    // The released version of glox
+	Commit     string   `json:"commit"`     // The git commit glox was built from
+	Date       string   `json:"date"`       // The time glox was built
+	Go         string   `json:"go"`         // The version of Go glox was compiled with
+	Extensions []string `json:"extensions"` // Supported language extensions
+}
+
+// versionFlags returns the flags accepted by 'glox version'.
+func versionFlags(asJSON *bool) *flag.FlagSet {
+	flags := flag.NewFlagSet("version", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), versionUsage)
+		flags.PrintDefaults()
+	}
+
+	flags.BoolVar(asJSON, "json", false, "Print build information as JSON")
+
+	return flags
+}
+
+// version implements the 'glox version' command.
+func (a App) version(args []string) error {
+	var asJSON bool
+	flags := versionFlags(&asJSON)
+
+	if done, err := a.parseFlags(flags, args); done {
+		return err
+	}
+
+	if flags.NArg() != 0 {
+		return fmt.Errorf("version takes no arguments, got %d", flags.NArg())
+	}
+
+	info := getBuildInfo()
+
+	if asJSON {
+		encoder := json.NewEncoder(a.stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(info)
+	}
+
+	tw := tabwriter.NewWriter(a.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Version:\t%s\n", info.Version)
+	fmt.Fprintf(tw, "Commit:\t%s\n", info.Commit)
+	fmt.Fprintf(tw, "Built:\t%s\n", info.Date)
+	fmt.Fprintf(tw, "Go:\t%s\n", info.Go)
+
+	return tw.Flush()
+}
+
+// getBuildInfo returns the build information for the running binary.
+//
+// Values not set at link time (e.g. under 'go install' or 'go run') are
+// filled in from the module and VCS information embedded by the Go toolchain
+// where available.
+func getBuildInfo() buildInfo {
+	info := buildInfo{
+		Version:    version,
+		Commit:     commit,
+		Date:       date,
+		Go:         runtime.Version(),
+		Extensions: extensions,
+	}
+
+	build, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+
+	if info.Version == "dev" && build.Main.Version != "" && build.Main.Version != "(devel)" {
+		info.Version = build.Main.Version
+	}
+
+	for _, setting := range build.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			if info.Commit == "" {
+				info.Commit = setting.Value
+			}
+		case "vcs.time":
+			if info.Date == "" {
+				info.Date = setting.Value
+			}
+		}
+	}
+
+	return info
+}
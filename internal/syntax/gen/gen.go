@@ -0,0 +1,390 @@
+// Package gen generates random, syntactically valid Lox programs.
+//
+// Programs are produced by walking the Lox grammar from Crafting Interpreters, making
+// random choices at every alternative. They are intended as input for fuzzing, stress
+// testing and benchmarking and so make no attempt to be meaningful, only well formed.
+package gen
+
+import (
+	"math/rand/v2"
+	"strconv"
+	"strings"
+)
+
+// names are the identifiers a generated program may use, keeping the pool small means
+// the same names are reused which is closer to what real programs look like.
+var names = [...]string{"a", "b", "c", "x", "y", "count", "name", "total", "item", "_tmp", "value2"}
+
+// binaryOps are the infix operators, excluding the logical 'and' and 'or'.
+var binaryOps = [...]string{"+", "-", "*", "/", "==", "!=", "<", "<=", ">", ">="}
+
+// Config controls the shape of generated programs.
+type Config struct {
+	Seed       uint64 // Seed for the random source, the same seed always generates the same program
+	Statements int    // Number of top level declarations to generate
+	MaxDepth   int    // Maximum nesting depth of statements and expressions
+}
+
+// Generator generates random Lox programs.
+type Generator struct {
+	rng        *rand.Rand      // Source of randomness
+	b          strings.Builder // The program being built
+	config     Config          // The configuration the generator was created with
+	depth      int             // Current nesting depth
+	indent     int             // Current indentation level
+	inFunction bool            // Whether we're inside a function body, where 'return' is allowed
+	inClass    bool            // Whether we're inside a method body, where 'this' is allowed
+}
+
+// New returns a new [Generator] configured by config.
+func New(config Config) *Generator {
+	return &Generator{
+		rng:    rand.New(rand.NewPCG(config.Seed, config.Seed)), //nolint:gosec // Determinism matters here, not security
+		config: config,
+	}
+}
+
+// Program generates and returns a complete Lox program.
+func (g *Generator) Program() string {
+	g.b.Reset()
+	g.depth, g.indent = 0, 0
+	g.inFunction, g.inClass = false, false
+
+	for range g.config.Statements {
+		g.declaration()
+	}
+
+	return g.b.String()
+}
+
+// declaration generates a single declaration: a class, function, variable or statement.
+func (g *Generator) declaration() {
+	if g.exhausted() {
+		g.varDecl()
+		return
+	}
+
+	switch g.rng.IntN(10) {
+	case 0:
+		g.classDecl()
+	case 1, 2:
+		g.line("fun ")
+		g.function()
+		g.write("\n")
+	case 3, 4:
+		g.varDecl()
+	default:
+		g.statement()
+	}
+}
+
+// classDecl generates a class declaration with an optional superclass and some methods.
+func (g *Generator) classDecl() {
+	name := g.className()
+	g.line("class " + name)
+	if super := g.className(); super != name && g.rng.IntN(3) == 0 {
+		g.write(" < " + super)
+	}
+	g.write(" {\n")
+
+	g.enter()
+	inClass := g.inClass
+	g.inClass = true
+	for range g.rng.IntN(3) {
+		g.line("")
+		g.function()
+		g.write("\n")
+	}
+	g.inClass = inClass
+	g.leave()
+
+	g.line("}\n")
+}
+
+// function generates a function name, parameter list and body, the "fun" keyword
+// is not included as it's omitted for methods.
+func (g *Generator) function() {
+	g.write(g.name() + "(")
+
+	// Parameter names must be unique so take them from a shuffled list of names
+	for i, param := range g.rng.Perm(len(names))[:g.rng.IntN(4)] {
+		if i > 0 {
+			g.write(", ")
+		}
+		g.write(names[param])
+	}
+	g.write(") ")
+
+	inFunction := g.inFunction
+	g.inFunction = true
+	g.block()
+	g.inFunction = inFunction
+}
+
+// varDecl generates a variable declaration, with or without an initialiser.
+func (g *Generator) varDecl() {
+	g.line("var " + g.name())
+	if g.rng.IntN(4) != 0 {
+		g.write(" = ")
+		g.expression()
+	}
+	g.write(";\n")
+}
+
+// statement generates a single statement.
+func (g *Generator) statement() {
+	if g.exhausted() {
+		g.line("print ")
+		g.expression()
+		g.write(";\n")
+		return
+	}
+
+	switch g.rng.IntN(8) {
+	case 0:
+		g.line("if (")
+		g.expression()
+		g.write(") ")
+		g.block()
+		if g.rng.IntN(2) == 0 {
+			g.write(" else ")
+			g.block()
+		}
+		g.write("\n")
+	case 1:
+		g.line("while (")
+		g.expression()
+		g.write(") ")
+		g.block()
+		g.write("\n")
+	case 2:
+		g.forStmt()
+	case 3:
+		g.line("")
+		g.block()
+		g.write("\n")
+	case 4:
+		g.line("print ")
+		g.expression()
+		g.write(";\n")
+	case 5:
+		if g.inFunction {
+			g.line("return")
+			if g.rng.IntN(3) != 0 {
+				g.write(" ")
+				g.expression()
+			}
+			g.write(";\n")
+			return
+		}
+		fallthrough
+	default:
+		g.line("")
+		g.expression()
+		g.write(";\n")
+	}
+}
+
+// forStmt generates a for loop, any of whose clauses may be omitted.
+func (g *Generator) forStmt() {
+	g.line("for (")
+	switch g.rng.IntN(3) {
+	case 0:
+		g.write(";")
+	case 1:
+		g.write("var " + g.name() + " = ")
+		g.expression()
+		g.write(";")
+	default:
+		g.expression()
+		g.write(";")
+	}
+
+	if g.rng.IntN(4) != 0 {
+		g.write(" ")
+		g.expression()
+	}
+	g.write(";")
+
+	if g.rng.IntN(4) != 0 {
+		g.write(" ")
+		g.expression()
+	}
+	g.write(") ")
+	g.block()
+	g.write("\n")
+}
+
+// block generates a brace delimited block of declarations, leaving the cursor directly
+// after the closing brace.
+func (g *Generator) block() {
+	g.write("{\n")
+	g.enter()
+	for range 1 + g.rng.IntN(3) {
+		g.declaration()
+	}
+	g.leave()
+	g.line("}")
+}
+
+// expression generates an expression.
+func (g *Generator) expression() {
+	g.depth++
+	defer func() { g.depth-- }()
+
+	if g.exhausted() {
+		g.primary()
+		return
+	}
+
+	switch g.rng.IntN(10) {
+	case 0:
+		// Assignment, either to a variable or a property
+		if g.rng.IntN(2) == 0 {
+			g.call()
+			g.write(".")
+		}
+		g.write(g.name() + " = ")
+		g.expression()
+	case 1:
+		g.operand()
+		if g.rng.IntN(2) == 0 {
+			g.write(" and ")
+		} else {
+			g.write(" or ")
+		}
+		g.operand()
+	case 2, 3:
+		g.operand()
+		g.write(" " + binaryOps[g.rng.IntN(len(binaryOps))] + " ")
+		g.operand()
+	case 4:
+		if g.rng.IntN(2) == 0 {
+			g.write("!")
+		} else {
+			g.write("-")
+		}
+		g.operand()
+	case 5:
+		g.call()
+	default:
+		g.primary()
+	}
+}
+
+// operand generates the operand of a unary, binary or logical operator.
+//
+// Anything other than a call or primary is parenthesised so that e.g. an assignment
+// can never end up as the left hand side of a binary operator, which would be an
+// invalid assignment target.
+func (g *Generator) operand() {
+	switch g.rng.IntN(3) {
+	case 0:
+		if !g.exhausted() {
+			g.write("(")
+			g.expression()
+			g.write(")")
+			return
+		}
+		fallthrough
+	case 1:
+		g.primary()
+	default:
+		g.call()
+	}
+}
+
+// call generates a call or property access chain.
+func (g *Generator) call() {
+	g.write(g.name())
+	for range 1 + g.rng.IntN(2) {
+		if g.rng.IntN(2) == 0 {
+			g.write("." + g.name())
+			continue
+		}
+
+		g.write("(")
+		for i := range g.rng.IntN(3) {
+			if i > 0 {
+				g.write(", ")
+			}
+			g.expression()
+		}
+		g.write(")")
+	}
+}
+
+// primary generates a literal, identifier or grouping.
+func (g *Generator) primary() {
+	switch g.rng.IntN(9) {
+	case 0:
+		g.write("true")
+	case 1:
+		g.write("false")
+	case 2:
+		g.write("nil")
+	case 3:
+		if g.inClass {
+			g.write("this")
+			return
+		}
+		fallthrough
+	case 4:
+		g.write(strconv.Itoa(g.rng.IntN(1000)))
+		if g.rng.IntN(3) == 0 {
+			g.write("." + strconv.Itoa(g.rng.IntN(100)))
+		}
+	case 5:
+		g.write(`"` + g.name() + `"`)
+	case 6:
+		if !g.exhausted() {
+			g.write("(")
+			g.expression()
+			g.write(")")
+			return
+		}
+		fallthrough
+	default:
+		g.write(g.name())
+	}
+}
+
+// name returns a random identifier.
+func (g *Generator) name() string {
+	return names[g.rng.IntN(len(names))]
+}
+
+// className returns a random class name.
+func (g *Generator) className() string {
+	name := g.name()
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+// exhausted reports whether the maximum nesting depth has been reached, at which
+// point only terminal productions are generated.
+func (g *Generator) exhausted() bool {
+	return g.depth >= g.config.MaxDepth
+}
+
+// enter increases the nesting depth and indentation, e.g. on entering a block.
+func (g *Generator) enter() {
+	g.depth++
+	g.indent++
+}
+
+// leave reverses a call to enter.
+func (g *Generator) leave() {
+	g.depth--
+	g.indent--
+}
+
+// line starts a new line at the current indentation, followed by text.
+func (g *Generator) line(text string) {
+	g.b.WriteString(strings.Repeat("    ", g.indent))
+	g.b.WriteString(text)
+}
+
+// write writes text to the program.
+func (g *Generator) write(text string) {
+	g.b.WriteString(text)
+}
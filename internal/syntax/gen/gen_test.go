@@ -0,0 +1,55 @@
+package gen_test
+
+import (
+	"testing"
+
+	"github.com/FollowTheProcess/glox/internal/syntax"
+	"github.com/FollowTheProcess/glox/internal/syntax/gen"
+	"github.com/FollowTheProcess/glox/internal/syntax/lexer"
+	"github.com/FollowTheProcess/glox/internal/syntax/token"
+	"github.com/FollowTheProcess/test"
+)
+
+func TestDeterministic(t *testing.T) {
+	config := gen.Config{Seed: 42, Statements: 20, MaxDepth: 5}
+
+	first := gen.New(config).Program()
+	second := gen.New(config).Program()
+
+	test.Equal(t, first, second)
+
+	config.Seed = 43
+	test.NotEqual(t, gen.New(config).Program(), first)
+}
+
+func TestValid(t *testing.T) {
+	for seed := range uint64(200) {
+		src := gen.New(gen.Config{Seed: seed, Statements: 10, MaxDepth: 6}).Program()
+		test.NotEqual(t, src, "")
+
+		handler := func(pos syntax.Position, msg string) {
+			t.Fatalf("seed %d: syntax error in generated program at %s: %s\n%s", seed, pos, msg, src)
+		}
+
+		// Every bracket opened must be closed in the right order
+		var stack []token.Kind
+		lex := lexer.New("gen.lox", src, handler)
+		for tok := lex.NextToken(); tok.Kind != token.EOF; tok = lex.NextToken() {
+			switch tok.Kind {
+			case token.OpenParen, token.OpenBrace:
+				stack = append(stack, tok.Kind)
+			case token.CloseParen, token.CloseBrace:
+				want := token.OpenParen
+				if tok.Kind == token.CloseBrace {
+					want = token.OpenBrace
+				}
+				if len(stack) == 0 || stack[len(stack)-1] != want {
+					t.Fatalf("seed %d: unbalanced %s at offset %d\n%s", seed, tok.Kind, tok.Start, src)
+				}
+				stack = stack[:len(stack)-1]
+			}
+		}
+
+		test.Equal(t, len(stack), 0, test.Context("seed %d: unclosed brackets", seed))
+	}
+}
@@ -10,33 +10,49 @@ import (
 	"github.com/FollowTheProcess/glox/internal/syntax/token"
 )
 
-const eof = rune(0) // eof signifies we have reached the end of the input.
+// eof signifies we have reached the end of the input. It must not be a valid rune
+// so that a NUL byte in the source is reported as an error rather than ending it.
+const eof = rune(-1)
 
 // Lexer is the lexer.
 type Lexer struct {
 	handler   syntax.ErrorHandler // The error handler, if any
+	file      *syntax.SourceFile  // Line index of src, built on first use
 	name      string              // Filename
 	src       string              // The raw source text
 	line      int                 // Current line number
-	lineStart int                 // Offset at which the current line started
+	startLine int                 // The line number the current token starts on
 	start     int                 // The starting offset of the current token
 	pos       int                 // The current position in src
-	width     int                 // The width of the last rune read, allows backup
 }
 
 // New returns a new [Lexer].
 func New(name, src string, handler syntax.ErrorHandler) *Lexer {
 	return &Lexer{
-		handler: handler,
-		src:     src,
-		name:    name,
-		line:    1,
+		handler:   handler,
+		src:       src,
+		name:      name,
+		line:      1,
+		startLine: 1,
 	}
 }
 
+// File returns the [syntax.SourceFile] for the source being lexed, from which
+// the line and column of any token can be found.
+//
+// The line index is built on the first call (or the first error) and shared
+// with the lexer thereafter, so callers need not index the source again.
+func (l *Lexer) File() *syntax.SourceFile {
+	if l.file == nil {
+		l.file = syntax.NewSourceFile(l.name, l.src)
+	}
+	return l.file
+}
+
 // NextToken returns the next token from the input stream.
 func (l *Lexer) NextToken() token.Token { //nolint:cyclop // Technically yes but this is clearly trivial
-	l.skip(unicode.IsSpace)
+	l.skipWhitespace()
+	l.startLine = l.line
 
 	switch char := l.next(); char {
 	case eof:
@@ -94,13 +110,16 @@ func (l *Lexer) next() rune {
 		return eof
 	}
 
-	char, width := utf8.DecodeRuneInString(l.src[l.pos:])
-	l.width = width
+	// The vast majority of Lox source is ASCII, so only decode utf8 if we have to
+	char, width := rune(l.src[l.pos]), 1
+	if char >= utf8.RuneSelf {
+		char, width = utf8.DecodeRuneInString(l.src[l.pos:])
+	}
+
 	l.pos += width
 
 	if char == '\n' {
 		l.line++
-		l.lineStart = l.pos
 	}
 
 	return char
@@ -112,6 +131,10 @@ func (l *Lexer) peek() rune {
 		return eof
 	}
 
+	if char := rune(l.src[l.pos]); char < utf8.RuneSelf {
+		return char
+	}
+
 	char, _ := utf8.DecodeRuneInString(l.src[l.pos:])
 	return char
 }
@@ -125,12 +148,35 @@ func (l *Lexer) skip(predicate func(r rune) bool) {
 	l.start = l.pos
 }
 
+// skipWhitespace is [Lexer.skip] with [unicode.IsSpace] but, as it runs before every
+// token, handles ASCII whitespace byte by byte without going through next and peek.
+func (l *Lexer) skipWhitespace() {
+	for l.pos < len(l.src) {
+		switch char := l.src[l.pos]; char {
+		case ' ', '\t', '\r', '\v', '\f':
+			l.pos++
+		case '\n':
+			l.pos++
+			l.line++
+		default:
+			if char < utf8.RuneSelf {
+				l.start = l.pos
+				return
+			}
+			// Possibly unicode whitespace, take the slow path
+			l.skip(unicode.IsSpace)
+			return
+		}
+	}
+	l.start = l.pos
+}
+
 // emit returns a [token.Token] of the given kind using the lexer's internal
 // state to fill in the position information.
 func (l *Lexer) emit(kind token.Kind) token.Token {
 	tok := token.Token{
 		Kind:  kind,
-		Line:  l.line,
+		Line:  l.startLine,
 		Start: l.start,
 		End:   l.pos,
 	}
@@ -147,20 +193,9 @@ func (l *Lexer) error(msg string) {
 		return
 	}
 
-	// Column is the number of bytes between the last newline and the current position
-	// +1 because columns are 1 indexed
-	startCol := 1 + l.start - l.lineStart
-	endCol := 1 + l.pos - l.lineStart
-
-	position := syntax.Position{
-		Name:     l.name,
-		Offset:   l.pos,
-		Line:     l.line,
-		StartCol: startCol,
-		EndCol:   endCol,
-	}
-
-	l.handler(position, msg)
+	// Errors are rare so only pay for indexing the source once we hit one, l.line
+	// alone isn't enough as the column is needed too
+	l.handler(l.File().Position(l.start, l.pos), msg)
 }
 
 // errorf calls error with a formatted message.
@@ -251,10 +286,26 @@ func (l *Lexer) scanNumber() token.Token {
 
 // scanIdent scans an identifier.
 func (l *Lexer) scanIdent() token.Token {
-	for isAlphaNumeric(l.peek()) {
+	for {
+		// Fast path for ASCII, which is almost every identifier
+		if l.pos < len(l.src) && l.src[l.pos] < utf8.RuneSelf {
+			if !isAlphaNumeric(rune(l.src[l.pos])) {
+				break
+			}
+			l.pos++
+			continue
+		}
+
+		if !isAlphaNumeric(l.peek()) {
+			break
+		}
 		l.next()
 	}
 
+	if kind, ok := token.Keyword(l.src[l.start:l.pos]); ok {
+		return l.emit(kind)
+	}
+
 	return l.emit(token.Ident)
 }
 
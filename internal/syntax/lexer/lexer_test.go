@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/FollowTheProcess/glox/internal/syntax"
+	"github.com/FollowTheProcess/glox/internal/syntax/gen"
 	"github.com/FollowTheProcess/glox/internal/syntax/lexer"
 	"github.com/FollowTheProcess/glox/internal/syntax/token"
 	"github.com/FollowTheProcess/test"
@@ -187,6 +188,35 @@ func TestBasics(t *testing.T) {
 				{Kind: token.Ident, Line: 1, Start: 0, End: 5},
 			},
 		},
+		{
+			name: "Keyword",
+			src:  "var",
+			want: []token.Token{
+				{Kind: token.Var, Line: 1, Start: 0, End: 3},
+			},
+		},
+		{
+			name: "Keyword while",
+			src:  "while",
+			want: []token.Token{
+				{Kind: token.While, Line: 1, Start: 0, End: 5},
+			},
+		},
+		{
+			name: "Ident with keyword prefix",
+			src:  "classy",
+			want: []token.Token{
+				{Kind: token.Ident, Line: 1, Start: 0, End: 6},
+			},
+		},
+		{
+			name: "Multiline string",
+			src:  "\"one\ntwo\"\nx",
+			want: []token.Token{
+				{Kind: token.String, Line: 1, Start: 0, End: 9},
+				{Kind: token.Ident, Line: 3, Start: 10, End: 11},
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -211,3 +241,170 @@ func testFailHandler(tb testing.TB) syntax.ErrorHandler {
 		tb.Fatalf("%s: %s\n", pos, msg)
 	}
 }
+
+func TestErrors(t *testing.T) {
+	tests := []struct {
+		name string   // Name of the test case
+		src  string   // Source code to scan
+		want []string // Expected errors, formatted as "position: message"
+	}{
+		{
+			name: "unexpected character",
+			src:  "x\n  $",
+			want: []string{"test.lox:2:3-4: unexpected character '$'"},
+		},
+		{
+			name: "unterminated string",
+			src:  "x = \"abc",
+			want: []string{"test.lox:1:5-9: unterminated string literal"},
+		},
+		{
+			name: "unterminated multiline string",
+			src:  "x\n\"abc\ndef",
+			want: []string{"test.lox:2:1-5: unterminated string literal"},
+		},
+		{
+			name: "NUL byte",
+			src:  "a\x00b c $",
+			want: []string{
+				"test.lox:1:2-3: unexpected character '\\x00'",
+				"test.lox:1:7-8: unexpected character '$'",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			handler := func(pos syntax.Position, msg string) {
+				test.True(t, pos.IsValid(), test.Context("invalid position %#v", pos))
+				got = append(got, pos.String()+": "+msg)
+			}
+
+			lex := lexer.New("test.lox", tt.src, handler)
+			for lex.NextToken().Kind != token.EOF {
+				// Errors are reported to the handler, we don't care about the tokens
+			}
+
+			test.EqualFunc(t, got, tt.want, slices.Equal)
+		})
+	}
+}
+
+func TestFile(t *testing.T) {
+	src := "var s = \"one\ntwo\";\nprint s;"
+	lex := lexer.New("test.lox", src, testFailHandler(t))
+
+	// Every token's line must agree with the shared line index
+	for tok := lex.NextToken(); tok.Kind != token.EOF; tok = lex.NextToken() {
+		line, _ := lex.File().LineCol(tok.Start)
+		test.Equal(t, tok.Line, line, test.Context("token %s", tok))
+	}
+
+	test.Equal(t, lex.File(), lex.File(), test.Context("File should only index the source once"))
+}
+
+func TestAllocations(t *testing.T) {
+	src := gen.New(gen.Config{Seed: 1, Statements: 100, MaxDepth: 5}).Program()
+
+	// The only allocation should be the Lexer itself, never one per token
+	allocs := testing.AllocsPerRun(10, func() {
+		lex := lexer.New("allocs.lox", src, nil)
+		for lex.NextToken().Kind != token.EOF {
+			// Just lexing, nothing to do with the tokens
+		}
+	})
+
+	test.True(t, allocs <= 1, test.Context("lexing allocated %v times, expected at most 1", allocs))
+}
+
+// BenchmarkLexer measures lexing throughput on generated Lox source of increasing size.
+//
+// As a rough guide the large corpus should lex at around 100 MB/s on a typical development
+// machine, smaller inputs vary more with the machine and are usually slower. Throughput is
+// not enforced anywhere, compare runs with benchstat to spot regressions. The single
+// allocation (the Lexer) per source file is enforced, see TestAllocations.
+func BenchmarkLexer(b *testing.B) {
+	benchmarks := []struct {
+		name       string // Name of the benchmark
+		statements int    // Number of top level declarations in the generated source
+	}{
+		{name: "small", statements: 10},
+		{name: "medium", statements: 1000},
+		{name: "large", statements: 100000},
+	}
+
+	for _, bb := range benchmarks {
+		src := gen.New(gen.Config{Seed: 1, Statements: bb.statements, MaxDepth: 5}).Program()
+
+		b.Run(bb.name, func(b *testing.B) {
+			b.SetBytes(int64(len(src)))
+			b.ReportAllocs()
+
+			for b.Loop() {
+				lex := lexer.New("bench.lox", src, testFailHandler(b))
+				for lex.NextToken().Kind != token.EOF {
+					// Just lexing, nothing to do with the tokens
+				}
+			}
+		})
+	}
+}
+
+func FuzzLexer(f *testing.F) {
+	corpus := []string{
+		"",
+		"var x = 1;",
+		"print \"hello\";",
+		"// just a comment",
+		"\"unterminated",
+		"3.14.15",
+		"x\n\t$ @ #",
+		"ünïcödé = \"✓\";",
+		"a\x00b c $",
+		"x // trailing comment",
+		"x \u00a0\n",
+	}
+	for seed := range uint64(10) {
+		corpus = append(corpus, gen.New(gen.Config{Seed: seed, Statements: 3, MaxDepth: 3}).Program())
+	}
+
+	for _, src := range corpus {
+		f.Add(src)
+	}
+
+	f.Fuzz(func(t *testing.T, src string) {
+		handler := func(pos syntax.Position, msg string) {
+			if !pos.IsValid() {
+				t.Fatalf("error %q reported at invalid position %#v", msg, pos)
+			}
+			if pos.Offset < 0 || pos.Offset > len(src) {
+				t.Fatalf("error %q reported at offset %d outside of source (len %d)", msg, pos.Offset, len(src))
+			}
+		}
+
+		lex := lexer.New("fuzz.lox", src, handler)
+
+		// Every token consumes at least one byte so we must hit EOF within len(src) + 1 tokens
+		end := 0
+		for range len(src) + 1 {
+			tok := lex.NextToken()
+			if tok.Start < end || tok.End < tok.Start || tok.End > len(src) {
+				t.Fatalf("token %s out of order or outside of source (len %d)", tok, len(src))
+			}
+			if tok.Kind == token.EOF {
+				// Trailing whitespace and comments are skipped, so EOF is always at the very end
+				if tok.Start != len(src) || tok.End != len(src) {
+					t.Fatalf("EOF token %s is not at the end of the source (len %d)", tok, len(src))
+				}
+				return
+			}
+			if tok.End == tok.Start {
+				t.Fatalf("non EOF token %s is empty", tok)
+			}
+			end = tok.End
+		}
+
+		t.Fatalf("lexer did not reach EOF after %d tokens", len(src)+1)
+	})
+}
@@ -0,0 +1,80 @@
+package syntax
+
+import "slices"
+
+// SourceFile is a named piece of Lox source, along with an index of the offset at which
+// every line starts.
+//
+// The index is built once, up front, so that converting a byte offset to a line and column
+// is a binary search rather than a rescan of the source each time.
+type SourceFile struct {
+	name  string // File name
+	src   string // The raw source text
+	lines []int  // Byte offset of the start of each line, lines[0] is always 0
+}
+
+// NewSourceFile returns a new [SourceFile], indexing the start of every line in src.
+func NewSourceFile(name, src string) *SourceFile {
+	lines := []int{0}
+	for i := range len(src) {
+		if src[i] == '\n' {
+			lines = append(lines, i+1)
+		}
+	}
+
+	return &SourceFile{
+		name:  name,
+		src:   src,
+		lines: lines,
+	}
+}
+
+// Name returns the name of the file.
+func (f *SourceFile) Name() string {
+	return f.name
+}
+
+// Lines returns the number of lines in the file.
+func (f *SourceFile) Lines() int {
+	return len(f.lines)
+}
+
+// LineCol returns the line and column (both 1 indexed) of the given byte offset.
+//
+// Offsets outside the source are clamped to its start or end.
+func (f *SourceFile) LineCol(offset int) (line, col int) {
+	offset = max(0, min(offset, len(f.src)))
+
+	// Find the last line starting at or before offset
+	i, found := slices.BinarySearch(f.lines, offset)
+	if !found {
+		i--
+	}
+
+	return i + 1, 1 + offset - f.lines[i]
+}
+
+// Position returns the [Position] of the source range [start, end).
+//
+// A range spanning more than one line is truncated to the end of the line
+// it starts on, as a [Position] only describes columns on a single line.
+func (f *SourceFile) Position(start, end int) Position {
+	line, startCol := f.LineCol(start)
+
+	// The end of the line start is on, excluding the newline
+	lineEnd := len(f.src)
+	if line < len(f.lines) {
+		lineEnd = f.lines[line] - 1
+	}
+
+	_, endCol := f.LineCol(min(max(end, start), lineEnd))
+	endCol = max(endCol, startCol)
+
+	return Position{
+		Name:     f.name,
+		Offset:   max(0, min(start, len(f.src))),
+		Line:     line,
+		StartCol: startCol,
+		EndCol:   endCol,
+	}
+}
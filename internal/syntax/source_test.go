@@ -0,0 +1,78 @@
+package syntax_test
+
+import (
+	"testing"
+
+	"github.com/FollowTheProcess/glox/internal/syntax"
+	"github.com/FollowTheProcess/test"
+)
+
+func TestLineCol(t *testing.T) {
+	file := syntax.NewSourceFile("test.lox", "var x;\n\nprint x;\n")
+
+	tests := []struct {
+		name   string // Name of the test case
+		offset int    // Byte offset to look up
+		line   int    // Expected line
+		col    int    // Expected column
+	}{
+		{name: "start", offset: 0, line: 1, col: 1},
+		{name: "first line", offset: 4, line: 1, col: 5},
+		{name: "newline", offset: 6, line: 1, col: 7},
+		{name: "empty line", offset: 7, line: 2, col: 1},
+		{name: "start of line", offset: 8, line: 3, col: 1},
+		{name: "middle of line", offset: 14, line: 3, col: 7},
+		{name: "end", offset: 17, line: 4, col: 1},
+		{name: "negative", offset: -3, line: 1, col: 1},
+		{name: "past end", offset: 100, line: 4, col: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			line, col := file.LineCol(tt.offset)
+			test.Equal(t, line, tt.line, test.Context("line"))
+			test.Equal(t, col, tt.col, test.Context("column"))
+		})
+	}
+
+	test.Equal(t, file.Name(), "test.lox")
+	test.Equal(t, file.Lines(), 4)
+}
+
+func TestSourceFilePosition(t *testing.T) {
+	file := syntax.NewSourceFile("test.lox", "x = \"one\ntwo\";\ny")
+
+	tests := []struct {
+		name  string // Name of the test case
+		want  string // Expected position string
+		start int    // Start offset of the range
+		end   int    // End offset of the range
+	}{
+		{name: "single char", start: 0, end: 1, want: "test.lox:1:1-2"},
+		{name: "empty range", start: 2, end: 2, want: "test.lox:1:3"},
+		{name: "multi line range", start: 4, end: 13, want: "test.lox:1:5-9"},
+		{name: "second line", start: 9, end: 14, want: "test.lox:2:1-6"},
+		{name: "last line", start: 15, end: 16, want: "test.lox:3:1-2"},
+		{name: "end before start", start: 2, end: 0, want: "test.lox:1:3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pos := file.Position(tt.start, tt.end)
+			test.True(t, pos.IsValid(), test.Context("position %#v is not valid", pos))
+			test.Equal(t, pos.String(), tt.want)
+		})
+	}
+}
+
+func BenchmarkLineCol(b *testing.B) {
+	src := make([]byte, 0, 1<<20)
+	for len(src) < cap(src)-32 {
+		src = append(src, "var x = 1 + 2; // a comment\n"...)
+	}
+	file := syntax.NewSourceFile("bench.lox", string(src))
+
+	for i := 0; b.Loop(); i++ {
+		file.LineCol(i % len(src))
+	}
+}
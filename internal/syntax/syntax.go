@@ -12,7 +12,7 @@ type ErrorHandler func(pos Position, msg string)
 // and EndCol, this is useful for error reporting.
 //
 // Position's without filenames are considered invalid, in the case of stdin
-// the string "stdin" may be used.
+// the string "<stdin>" may be used.
 type Position struct {
 	Name     string // File name
 	Offset   int    // Byte offset of the current position from the start of the file
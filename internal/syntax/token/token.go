@@ -53,7 +53,7 @@ const (
 // Token represents a single lexical token.
 type Token struct {
 	Kind  Kind // The token kind
-	Line  int  // The line number (starting at 1)
+	Line  int  // The line number the token starts on (starting at 1)
 	Start int  // Byte offset of the first character of the Token
 	End   int  // Byte offset of the last character in the Token (=Start for 1 char tokens)
 }
@@ -62,3 +62,29 @@ type Token struct {
 func (t Token) String() string {
 	return fmt.Sprintf("<Token::%s line=%d start=%d end=%d>", t.Kind, t.Line, t.Start, t.End)
 }
+
+// keywords maps the source text of each Lox reserved word to its [Kind].
+var keywords = map[string]Kind{
+	"and":    And,
+	"class":  Class,
+	"else":   Else,
+	"false":  False,
+	"fun":    Fun,
+	"for":    For,
+	"if":     If,
+	"nil":    Nil,
+	"or":     Or,
+	"print":  Print,
+	"return": Return,
+	"super":  Super,
+	"this":   This,
+	"true":   True,
+	"var":    Var,
+	"while":  While,
+}
+
+// Keyword reports whether ident is a Lox reserved word, returning its [Kind] if so.
+func Keyword(ident string) (Kind, bool) {
+	kind, ok := keywords[ident]
+	return kind, ok
+}
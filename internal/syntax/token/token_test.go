@@ -23,3 +23,31 @@ func TestString(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestKeyword(t *testing.T) {
+	tests := []struct {
+		ident string     // The identifier to look up
+		want  token.Kind // Expected kind, if a keyword
+		ok    bool       // Whether ident is expected to be a keyword
+	}{
+		{ident: "and", want: token.And, ok: true},
+		{ident: "class", want: token.Class, ok: true},
+		{ident: "fun", want: token.Fun, ok: true},
+		{ident: "while", want: token.While, ok: true},
+		{ident: "While", ok: false},
+		{ident: "variable", ok: false},
+		{ident: "", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ident, func(t *testing.T) {
+			got, ok := token.Keyword(tt.ident)
+			if ok != tt.ok {
+				t.Fatalf("Keyword(%q) ok = %v, wanted %v", tt.ident, ok, tt.ok)
+			}
+			if got != tt.want {
+				t.Errorf("Keyword(%q) = %s, wanted %s", tt.ident, got, tt.want)
+			}
+		})
+	}
+}